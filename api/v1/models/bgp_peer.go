@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package models holds the (hand-maintained, until regenerated from the
+// OpenAPI spec) subset of API types needed by the BGP control plane.
+package models
+
+// BgpPeer reports the observed state of a single BGP peering session.
+type BgpPeer struct {
+	LocalAsn                       int64                      `json:"local-asn,omitempty"`
+	PeerAsn                        int64                      `json:"peer-asn,omitempty"`
+	PeerAddress                    string                     `json:"peer-address,omitempty"`
+	SessionState                   string                     `json:"session-state,omitempty"`
+	ConnectRetryTimeSeconds        int64                      `json:"connect-retry-time-seconds,omitempty"`
+	ConfiguredHoldTimeSeconds      int64                      `json:"configured-hold-time-seconds,omitempty"`
+	ConfiguredKeepAliveTimeSeconds int64                      `json:"configured-keep-alive-time-seconds,omitempty"`
+	EbgpMultihopTTL                int64                      `json:"ebgp-multihop-ttl,omitempty"`
+	GracefulRestart                *BgpPeerGracefulRestart    `json:"graceful-restart,omitempty"`
+}
+
+// BgpPeerGracefulRestart reports the observed graceful restart state of a
+// BgpPeer.
+type BgpPeerGracefulRestart struct {
+	Enabled            bool  `json:"enabled,omitempty"`
+	RestartTimeSeconds int64 `json:"restart-time-seconds,omitempty"`
+}
+
+// GetPeerStateResponse is returned by a BGP router backend's GetPeerState.
+type GetPeerStateResponse struct {
+	Peers []*BgpPeer `json:"peers,omitempty"`
+}
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package models
+
+// ClusterMeshStatus reports the aggregate status of the ClusterMesh
+// subsystem, across every configured remote cluster.
+type ClusterMeshStatus struct {
+	NumGlobalServices int64             `json:"num-global-services,omitempty"`
+	Clusters          []*RemoteCluster  `json:"clusters,omitempty"`
+}
+
+// RemoteCluster reports the observed status of a single remote cluster.
+type RemoteCluster struct {
+	// Name is the name of the remote cluster.
+	Name string `json:"name,omitempty"`
+
+	// Ready reports whether the connection to this remote cluster has
+	// completed its initial sync.
+	Ready bool `json:"ready,omitempty"`
+
+	// Endpoints reports the health of every known kvstore endpoint
+	// advertised by this remote cluster.
+	Endpoints []*RemoteClusterEndpointStatus `json:"endpoints,omitempty"`
+}
+
+// RemoteClusterEndpointStatus reports the health of a single kvstore
+// endpoint of a remote cluster, as tracked by the connection balancer.
+type RemoteClusterEndpointStatus struct {
+	Endpoint string `json:"endpoint,omitempty"`
+	Healthy  bool   `json:"healthy,omitempty"`
+}
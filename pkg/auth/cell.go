@@ -114,7 +114,7 @@ func newManager(params authManagerParams) error {
 
 	registerReAuthenticationJob(jobGroup, mgr, params.AuthHandlers)
 
-	mapGC := newAuthMapGC(params.Logger, mapCache, params.IPCache)
+	mapGC := newAuthMapGC(params.Logger, mapCache)
 
 	registerGCJobs(jobGroup, mapGC, params)
 
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/k8s/resource"
+	"github.com/cilium/cilium/pkg/maps/authmap"
+)
+
+// authMapGarbageCollector removes auth entries that have expired, or whose
+// identity has disappeared.
+type authMapGarbageCollector struct {
+	logger   logrus.FieldLogger
+	mapCache *authMapCache
+}
+
+func newAuthMapGC(logger logrus.FieldLogger, mapCache *authMapCache) *authMapGarbageCollector {
+	return &authMapGarbageCollector{
+		logger:   logger,
+		mapCache: mapCache,
+	}
+}
+
+// CleanupExpiredEntries sweeps the auth map cache for entries whose
+// Expiration has passed and removes them through GuaranteedUpdate, so that
+// a concurrent re-authentication of the very same key is never dropped by
+// the sweep clobbering it out from under a fresher write.
+func (gc *authMapGarbageCollector) CleanupExpiredEntries(ctx context.Context) error {
+	now := time.Now()
+
+	for _, key := range gc.mapCache.expiredKeys(now) {
+		err := gc.mapCache.GuaranteedUpdate(ctx, key, nil, true, func(current *authmap.AuthInfo) (*authmap.AuthInfo, error) {
+			if current == nil || current.Expiration.After(now) {
+				// Someone re-authenticated this key since expiredKeys()
+				// took its snapshot: leave the fresher entry alone.
+				return current, nil
+			}
+			return nil, nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to garbage collect auth key %+v: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// handleCiliumIdentityEvent removes every cached auth entry referencing an
+// identity once its CiliumIdentity is deleted, so a reused or since-revoked
+// identity can never be authenticated against a stale auth map entry.
+// CiliumIdentity objects are named after the numeric identity they
+// represent, matching the convention the identity allocator itself uses.
+func (gc *authMapGarbageCollector) handleCiliumIdentityEvent(ctx context.Context, event resource.Event[*ciliumv2.CiliumIdentity]) error {
+	defer event.Done(nil)
+
+	if event.Kind != resource.Delete {
+		return nil
+	}
+
+	id, err := strconv.ParseUint(event.Object.Name, 10, 32)
+	if err != nil {
+		gc.logger.WithError(err).WithField("identity", event.Object.Name).
+			Warning("Ignoring CiliumIdentity delete with a non-numeric name during auth map GC")
+		return nil
+	}
+
+	for _, key := range gc.mapCache.keysForIdentity(uint32(id)) {
+		err := gc.mapCache.GuaranteedUpdate(ctx, key, nil, true, func(current *authmap.AuthInfo) (*authmap.AuthInfo, error) {
+			return nil, nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to garbage collect auth key %+v for deleted identity %d: %w", key, id, err)
+		}
+	}
+
+	return nil
+}
+
+// handleCiliumNodeEvent is wired up as a placeholder for node-reachability
+// GC: removing auth entries whose remoteNodeID belongs to a node that left
+// the cluster. Doing so requires correlating a CiliumNode with the node ID
+// the datapath assigned it, which nothing in this package resolves yet.
+//
+// TODO: implement once a node-ID lookup is available here, mirroring
+// handleCiliumIdentityEvent.
+func (gc *authMapGarbageCollector) handleCiliumNodeEvent(ctx context.Context, event resource.Event[*ciliumv2.CiliumNode]) error {
+	event.Done(nil)
+	return nil
+}
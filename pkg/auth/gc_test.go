@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/k8s/resource"
+	"github.com/cilium/cilium/pkg/maps/authmap"
+)
+
+func newTestAuthMapGC(cache *authMapCache) *authMapGarbageCollector {
+	return newAuthMapGC(logrus.NewEntry(logrus.New()), cache)
+}
+
+func identityEvent(kind resource.EventKind, name string) resource.Event[*ciliumv2.CiliumIdentity] {
+	return resource.Event[*ciliumv2.CiliumIdentity]{
+		Kind:   kind,
+		Object: &ciliumv2.CiliumIdentity{ObjectMeta: metav1.ObjectMeta{Name: name}},
+		Done:   func(error) {},
+	}
+}
+
+func TestHandleCiliumIdentityEventRemovesMatchingKeys(t *testing.T) {
+	cache := newTestAuthMapCache()
+	gc := newTestAuthMapGC(cache)
+
+	local := authKey{localIdentity: 42, remoteIdentity: 7}
+	remote := authKey{localIdentity: 7, remoteIdentity: 42}
+	unrelated := authKey{localIdentity: 1, remoteIdentity: 2}
+
+	expiration := time.Now().Add(time.Hour)
+	cache.cache[local] = &authmap.AuthInfo{Expiration: expiration}
+	cache.cache[remote] = &authmap.AuthInfo{Expiration: expiration}
+	cache.cache[unrelated] = &authmap.AuthInfo{Expiration: expiration}
+
+	require.NoError(t, gc.handleCiliumIdentityEvent(context.Background(), identityEvent(resource.Delete, "42")))
+
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	_, localStillPresent := cache.cache[local]
+	_, remoteStillPresent := cache.cache[remote]
+	_, unrelatedStillPresent := cache.cache[unrelated]
+
+	require.False(t, localStillPresent)
+	require.False(t, remoteStillPresent)
+	require.True(t, unrelatedStillPresent, "keys for unrelated identities must not be touched")
+}
+
+func TestHandleCiliumIdentityEventIgnoresNonDeleteAndNonNumericNames(t *testing.T) {
+	cache := newTestAuthMapCache()
+	gc := newTestAuthMapGC(cache)
+
+	key := authKey{localIdentity: 42, remoteIdentity: 7}
+	cache.cache[key] = &authmap.AuthInfo{Expiration: time.Now().Add(time.Hour)}
+
+	require.NoError(t, gc.handleCiliumIdentityEvent(context.Background(), identityEvent(resource.Upsert, "42")))
+	require.NoError(t, gc.handleCiliumIdentityEvent(context.Background(), identityEvent(resource.Delete, "not-a-number")))
+
+	cache.mutex.RLock()
+	_, present := cache.cache[key]
+	cache.mutex.RUnlock()
+	require.True(t, present, "an upsert event or a non-numeric name must never remove an entry")
+}
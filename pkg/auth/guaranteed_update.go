@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/cilium/cilium/pkg/maps/authmap"
+)
+
+// maxGuaranteedUpdateRetries bounds the number of CAS retries performed by
+// GuaranteedUpdate before giving up. It exists only to turn a pathological
+// case (an update loop that never converges) into a bounded error instead
+// of an infinite retry loop; under normal contention a handful of retries
+// is more than enough.
+const maxGuaranteedUpdateRetries = 5
+
+// copyAuthInfo returns a shallow copy of info, so that a tryUpdate callback
+// handed the copy cannot mutate the cache's own copy in place and defeat
+// the CAS check below.
+func copyAuthInfo(info *authmap.AuthInfo) *authmap.AuthInfo {
+	if info == nil {
+		return nil
+	}
+	copied := *info
+	return &copied
+}
+
+// GuaranteedUpdate performs a read-modify-write of the auth entry for key,
+// guaranteeing that tryUpdate observes and replaces the most recent value
+// even if other goroutines (the GC sweep, a concurrent re-authentication)
+// are racing to update the very same key. This mirrors the
+// GuaranteedUpdate pattern used by the k8s apiserver against etcd3: the
+// pre-image handed to tryUpdate is compared against what is still in the
+// cache immediately before writing, and the whole read-update-write is
+// retried from a fresh read on mismatch, rather than clobbering a
+// concurrent writer's change.
+//
+// If origState is already known to be current (the caller just read it
+// itself), pass mustCheckData=false to skip the redundant read on the
+// first attempt.
+//
+// tryUpdate may return a nil *authmap.AuthInfo to delete the entry for key
+// instead of writing one.
+func (c *authMapCache) GuaranteedUpdate(
+	ctx context.Context,
+	key authKey,
+	origState *authmap.AuthInfo,
+	mustCheckData bool,
+	tryUpdate func(current *authmap.AuthInfo) (*authmap.AuthInfo, error),
+) error {
+	current := origState
+
+	for attempt := 0; attempt < maxGuaranteedUpdateRetries; attempt++ {
+		if mustCheckData || current == nil {
+			c.mutex.RLock()
+			current = c.cache[key]
+			c.mutex.RUnlock()
+		}
+
+		// Snapshot the pre-image tryUpdate is about to see, and hand it a
+		// copy rather than the live cache entry: tryUpdate must not be able
+		// to mutate the cache's own value in place, or the comparison below
+		// would end up comparing the mutated entry to itself and never
+		// detect a conflict.
+		preImage := current
+
+		updated, err := tryUpdate(copyAuthInfo(preImage))
+		if err != nil {
+			return fmt.Errorf("tryUpdate failed for auth key %+v: %w", key, err)
+		}
+
+		c.mutex.Lock()
+		// Compare the pre-image tryUpdate saw against what is still in the
+		// cache by value, not by pointer identity: a concurrent writer that
+		// replaced the entry with a byte-identical copy must not cause a
+		// spurious retry, and a mutated-in-place pre-image must not be
+		// mistaken for an unchanged one.
+		if !reflect.DeepEqual(c.cache[key], preImage) {
+			// Someone else wrote in between our read and this lock: retry
+			// against whatever is there now.
+			c.mutex.Unlock()
+			mustCheckData = true
+			current = nil
+			continue
+		}
+
+		if updated == nil {
+			if err := c.mapWriter.delete(key); err != nil {
+				c.mutex.Unlock()
+				return fmt.Errorf("failed to delete auth entry for key %+v: %w", key, err)
+			}
+			delete(c.cache, key)
+			c.mutex.Unlock()
+			return nil
+		}
+
+		if err := c.mapWriter.insert(key, updated); err != nil {
+			c.mutex.Unlock()
+			return fmt.Errorf("failed to write auth entry for key %+v: %w", key, err)
+		}
+		c.cache[key] = updated
+		c.mutex.Unlock()
+
+		return nil
+	}
+
+	return fmt.Errorf("failed to guarantee update of auth key %+v after %d attempts due to concurrent writers", key, maxGuaranteedUpdateRetries)
+}
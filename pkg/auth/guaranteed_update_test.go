@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/maps/authmap"
+)
+
+// fakeAuthMap is an in-memory stand-in for the real BPF-backed authmap.Map,
+// used so these tests can exercise authMapCache/GuaranteedUpdate without a
+// kernel.
+type fakeAuthMap struct {
+	mutex   lock.Mutex
+	entries map[authmap.AuthKey]authmap.AuthInfo
+}
+
+func newFakeAuthMap() *fakeAuthMap {
+	return &fakeAuthMap{entries: make(map[authmap.AuthKey]authmap.AuthInfo)}
+}
+
+func (m *fakeAuthMap) Update(key authmap.AuthKey, info authmap.AuthInfo) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.entries[key] = info
+	return nil
+}
+
+func (m *fakeAuthMap) Delete(key authmap.AuthKey) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *fakeAuthMap) IterateWithCallback(cb func(authmap.AuthKey, authmap.AuthInfo)) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for k, v := range m.entries {
+		cb(k, v)
+	}
+	return nil
+}
+
+func newTestAuthMapCache() *authMapCache {
+	logger := logrus.NewEntry(logrus.New())
+	return newAuthMapCache(logger, newAuthMapWriter(logger, newFakeAuthMap()))
+}
+
+// TestGuaranteedUpdateByteIdenticalPreImage confirms that GuaranteedUpdate
+// compares the pre-image it was handed against the cache by value, not by
+// pointer identity: a distinct pointer carrying byte-identical content must
+// not trigger a spurious retry.
+func TestGuaranteedUpdateByteIdenticalPreImage(t *testing.T) {
+	cache := newTestAuthMapCache()
+	key := authKey{localIdentity: 1, remoteIdentity: 2}
+
+	expiration := time.Now().Add(time.Hour)
+	cache.cache[key] = &authmap.AuthInfo{Expiration: expiration}
+
+	// A distinct pointer with byte-identical contents to what's cached.
+	preImage := &authmap.AuthInfo{Expiration: expiration}
+
+	var attempts int32
+	err := cache.GuaranteedUpdate(context.Background(), key, preImage, false, func(current *authmap.AuthInfo) (*authmap.AuthInfo, error) {
+		atomic.AddInt32(&attempts, 1)
+		return &authmap.AuthInfo{Expiration: expiration.Add(time.Hour)}, nil
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, attempts, "a byte-identical pre-image must not cause a retry")
+}
+
+// TestGuaranteedUpdateTryUpdateCannotMutateCacheInPlace confirms that
+// tryUpdate is handed a copy of the cached entry, not the live cached
+// pointer: mutating it in place must not corrupt the cache before the
+// write is committed, and must not be mistaken for "unchanged" by the CAS
+// check.
+func TestGuaranteedUpdateTryUpdateCannotMutateCacheInPlace(t *testing.T) {
+	cache := newTestAuthMapCache()
+	key := authKey{localIdentity: 1, remoteIdentity: 2}
+
+	original := time.Now().Add(time.Hour)
+	cache.cache[key] = &authmap.AuthInfo{Expiration: original}
+
+	mutated := original.Add(time.Hour)
+	err := cache.GuaranteedUpdate(context.Background(), key, nil, true, func(current *authmap.AuthInfo) (*authmap.AuthInfo, error) {
+		current.Expiration = mutated // mutate the pre-image handed to us
+
+		cache.mutex.RLock()
+		cached := cache.cache[key].Expiration
+		cache.mutex.RUnlock()
+		require.Equal(t, original, cached, "mutating the pre-image must not change the cached entry before the write")
+
+		return current, nil
+	})
+	require.NoError(t, err)
+
+	cache.mutex.RLock()
+	got := cache.cache[key]
+	cache.mutex.RUnlock()
+
+	require.Equal(t, mutated, got.Expiration, "the mutated value must still be the one written back")
+}
+
+// TestGuaranteedUpdateInterleavedGCAndReauth confirms that a GC sweep
+// deciding to delete a key based on a stale (already expired) snapshot
+// cannot clobber a concurrent re-authentication of that same identity pair:
+// GuaranteedUpdate must detect the change underneath it and retry against
+// the fresh value instead of silently dropping it.
+func TestGuaranteedUpdateInterleavedGCAndReauth(t *testing.T) {
+	cache := newTestAuthMapCache()
+	key := authKey{localIdentity: 42, remoteIdentity: 7}
+
+	now := time.Now()
+	cache.cache[key] = &authmap.AuthInfo{Expiration: now.Add(-time.Minute)} // already expired
+
+	started := make(chan struct{})
+	reauthDone := make(chan struct{})
+	var calls int32
+
+	gcTryUpdate := func(current *authmap.AuthInfo) (*authmap.AuthInfo, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-reauthDone
+		}
+		if current == nil || current.Expiration.After(now) {
+			// Re-authenticated since our snapshot was taken: leave it.
+			return current, nil
+		}
+		return nil, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := cache.GuaranteedUpdate(context.Background(), key, nil, true, gcTryUpdate)
+		require.NoError(t, err)
+	}()
+
+	<-started
+
+	freshExpiration := now.Add(time.Hour)
+	err := cache.GuaranteedUpdate(context.Background(), key, nil, true, func(current *authmap.AuthInfo) (*authmap.AuthInfo, error) {
+		return &authmap.AuthInfo{Expiration: freshExpiration}, nil
+	})
+	require.NoError(t, err)
+	close(reauthDone)
+
+	wg.Wait()
+
+	cache.mutex.RLock()
+	got, ok := cache.cache[key]
+	cache.mutex.RUnlock()
+
+	require.True(t, ok, "GC must not delete the entry re-authenticated underneath it")
+	require.Equal(t, freshExpiration, got.Expiration)
+	require.GreaterOrEqual(t, atomic.LoadInt32(&calls), int32(2), "GC must retry once it observes the concurrent write")
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cilium/cilium/pkg/ipcache"
+	"github.com/cilium/cilium/pkg/maps/authmap"
+)
+
+// signalAuthKey is the wire shape of an "auth required" signal received
+// from the datapath, before it has been resolved into an authKey.
+type signalAuthKey struct {
+	LocalIdentity  uint32
+	RemoteIdentity uint32
+	RemoteNodeID   uint16
+}
+
+func (s signalAuthKey) authKey() authKey {
+	return authKey{
+		localIdentity:  s.LocalIdentity,
+		remoteIdentity: s.RemoteIdentity,
+		remoteNodeID:   s.RemoteNodeID,
+	}
+}
+
+// certificateRotationEvent notifies the manager that the certificate
+// backing an identity was rotated, invalidating any auth entries keyed on
+// it.
+type certificateRotationEvent struct {
+	Identity uint32
+}
+
+// authHandler performs the actual auth handshake for a configured auth
+// type, and, for handlers backed by rotating certificates, publishes
+// rotation events for identities whose auth entries must be redone.
+type authHandler interface {
+	authenticate(ctx context.Context, key authKey) (*authmap.AuthInfo, error)
+	subscribeToRotatedIdentities() <-chan certificateRotationEvent
+}
+
+// authManager drives request authentication and re-authentication,
+// persisting the outcome of both into the authMapCache.
+type authManager struct {
+	logger       logrus.FieldLogger
+	authHandlers []authHandler
+	mapCache     *authMapCache
+	ipCache      *ipcache.IPCache
+}
+
+func newAuthManager(logger logrus.FieldLogger, authHandlers []authHandler, mapCache *authMapCache, ipCache *ipcache.IPCache) (*authManager, error) {
+	if len(authHandlers) == 0 {
+		return nil, fmt.Errorf("at least one auth handler is required")
+	}
+
+	return &authManager{
+		logger:       logger,
+		authHandlers: authHandlers,
+		mapCache:     mapCache,
+		ipCache:      ipCache,
+	}, nil
+}
+
+// handleAuthRequest authenticates the identity pair named by key and
+// persists the outcome through GuaranteedUpdate, so that a concurrent GC
+// sweep racing on the very same key can never clobber the freshly
+// authenticated entry with a stale pre-image.
+func (m *authManager) handleAuthRequest(ctx context.Context, key signalAuthKey) error {
+	authKey := key.authKey()
+
+	for _, handler := range m.authHandlers {
+		if handler == nil {
+			continue
+		}
+
+		info, err := handler.authenticate(ctx, authKey)
+		if err != nil {
+			return fmt.Errorf("failed to authenticate auth key %+v: %w", authKey, err)
+		}
+		if info == nil {
+			continue
+		}
+
+		return m.mapCache.GuaranteedUpdate(ctx, authKey, nil, true, func(current *authmap.AuthInfo) (*authmap.AuthInfo, error) {
+			return info, nil
+		})
+	}
+
+	return fmt.Errorf("no auth handler produced a result for auth key %+v", authKey)
+}
+
+// handleCertificateRotationEvent invalidates every cached auth entry
+// referencing the rotated identity, so that the next request against it
+// re-authenticates against the new certificate. It goes through
+// GuaranteedUpdate so the invalidation can never race a concurrent GC
+// sweep or an in-flight re-authentication of the same key into leaving a
+// stale entry behind.
+func (m *authManager) handleCertificateRotationEvent(ctx context.Context, event certificateRotationEvent) error {
+	for _, key := range m.mapCache.keysForIdentity(event.Identity) {
+		err := m.mapCache.GuaranteedUpdate(ctx, key, nil, true, func(current *authmap.AuthInfo) (*authmap.AuthInfo, error) {
+			// The rotated certificate invalidates this entry outright;
+			// the next request will re-authenticate and repopulate it.
+			return nil, nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to invalidate auth key %+v after certificate rotation: %w", key, err)
+		}
+	}
+
+	return nil
+}
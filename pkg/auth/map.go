@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package auth
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/maps/authmap"
+)
+
+// authKey identifies a single cached auth entry by the identity pair and
+// remote node it authenticates traffic between.
+type authKey struct {
+	localIdentity  uint32
+	remoteIdentity uint32
+	remoteNodeID   uint16
+}
+
+func toMapKey(key authKey) authmap.AuthKey {
+	return authmap.AuthKey{
+		LocalIdentity:  key.localIdentity,
+		RemoteIdentity: key.remoteIdentity,
+		RemoteNodeID:   key.remoteNodeID,
+	}
+}
+
+func fromMapKey(key authmap.AuthKey) authKey {
+	return authKey{
+		localIdentity:  key.LocalIdentity,
+		remoteIdentity: key.RemoteIdentity,
+		remoteNodeID:   key.RemoteNodeID,
+	}
+}
+
+// authMapWriter writes through to the underlying BPF auth map.
+type authMapWriter struct {
+	logger  logrus.FieldLogger
+	authMap authmap.Map
+}
+
+func newAuthMapWriter(logger logrus.FieldLogger, authMap authmap.Map) *authMapWriter {
+	return &authMapWriter{logger: logger, authMap: authMap}
+}
+
+func (w *authMapWriter) insert(key authKey, info *authmap.AuthInfo) error {
+	return w.authMap.Update(toMapKey(key), *info)
+}
+
+func (w *authMapWriter) delete(key authKey) error {
+	return w.authMap.Delete(toMapKey(key))
+}
+
+// authMapCache caches the contents of the auth BPF map in user space, so
+// that reads (e.g. GetPeerState-style status queries, GC sweeps) don't need
+// to go through the map syscall interface. All writes go through
+// GuaranteedUpdate to keep the cache and the underlying map consistent
+// under concurrent writers.
+type authMapCache struct {
+	logger logrus.FieldLogger
+
+	mutex     lock.RWMutex
+	cache     map[authKey]*authmap.AuthInfo
+	mapWriter *authMapWriter
+}
+
+func newAuthMapCache(logger logrus.FieldLogger, mapWriter *authMapWriter) *authMapCache {
+	return &authMapCache{
+		logger:    logger,
+		cache:     make(map[authKey]*authmap.AuthInfo),
+		mapWriter: mapWriter,
+	}
+}
+
+// restoreCache populates the cache from the current contents of the
+// underlying BPF map, so that entries written by a previous agent run are
+// not forgotten across a restart.
+func (c *authMapCache) restoreCache() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.mapWriter.authMap.IterateWithCallback(func(key authmap.AuthKey, info authmap.AuthInfo) {
+		entry := info
+		c.cache[fromMapKey(key)] = &entry
+	})
+}
+
+// expiredKeys returns a snapshot of every key whose cached entry has
+// expired as of now. The snapshot may be stale by the time the caller acts
+// on it; GuaranteedUpdate is what makes acting on it safe.
+func (c *authMapCache) expiredKeys(now time.Time) []authKey {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var keys []authKey
+	for k, v := range c.cache {
+		if v != nil && !v.Expiration.After(now) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// keysForIdentity returns a snapshot of every cached key referencing the
+// given identity, either as the local or the remote side.
+func (c *authMapCache) keysForIdentity(id uint32) []authKey {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var keys []authKey
+	for k := range c.cache {
+		if k.localIdentity == id || k.remoteIdentity == id {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
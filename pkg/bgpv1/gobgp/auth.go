@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package gobgp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"unsafe"
+
+	gobgpapi "github.com/osrg/gobgp/v3/api"
+	"golang.org/x/sys/unix"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// tcpMD5SigMaxKeyLen is the kernel's TCP_MD5SIG_MAXKEYLEN, the largest
+// password accepted by the TCP_MD5SIG socket option.
+const tcpMD5SigMaxKeyLen = 80
+
+// errListenerUnavailable is returned by enableListenerMD5 when the server
+// has no TCP listener to apply the socket option to. gobgp does not expose
+// its own passive listener through its public API, so TCP_MD5SIG can only
+// be set when one has been supplied to this package directly. Callers treat
+// this as non-fatal: gobgp's own Transport.Password, already applied to
+// every AddPeer/UpdatePeer request by applyNeighborAuth, remains the
+// primary mechanism enforcing the shared secret; the kernel-level option is
+// best-effort hardening on top of it where a listener is available.
+var errListenerUnavailable = errors.New("no TCP listener available to apply the TCP MD5 signature option to")
+
+// neighborAuthPasswordKey is the well-known key under which the RFC 2385
+// TCP MD5 shared secret is stored in the referenced Secret's Data map, in
+// line with how other transport secrets (e.g. the etcd clustermesh
+// certificates) are laid out.
+const neighborAuthPasswordKey = "password"
+
+// resolveNeighborPassword resolves the TCP MD5 shared secret for a
+// CiliumBGPNeighbor from its AuthSecretRef, returning an empty string and a
+// nil error if the neighbor has no auth configured. It never returns the
+// resolved password in an error value, so that a failed lookup cannot leak
+// the secret's name/namespace pairing beyond what was already visible in
+// the CiliumBGPPeeringPolicy.
+func resolveNeighborPassword(ctx context.Context, k8sClient kubernetes.Interface, namespace, name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+
+	secret, err := k8sClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve BGP neighbor auth secret %s/%s: %w", namespace, name, err)
+	}
+
+	return extractPassword(secret)
+}
+
+func extractPassword(secret *corev1.Secret) (string, error) {
+	password, ok := secret.Data[neighborAuthPasswordKey]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s does not contain a %q key", secret.Namespace, secret.Name, neighborAuthPasswordKey)
+	}
+	return string(password), nil
+}
+
+// applyNeighborAuth configures the gobgp peer's transport-level
+// authentication in-place, based on the resolved password. Passing an
+// empty password disables authentication on the peer.
+func applyNeighborAuth(peer *gobgpapi.Peer, password string) {
+	if peer.Transport == nil {
+		peer.Transport = &gobgpapi.Transport{}
+	}
+	peer.Transport.PasswordEnabled = password != ""
+	peer.Transport.Password = password
+}
+
+// enableListenerMD5 sets the TCP_MD5SIG socket option for the given peer
+// address on l, so that the kernel signs/validates the TCP segments of that
+// specific BGP session. It is a no-op if password is empty, and returns
+// errListenerUnavailable if l is nil.
+//
+// This talks to the kernel directly through golang.org/x/sys/unix rather
+// than gobgp's own config package, which lives under gobgp's internal/ tree
+// and cannot be imported from outside the gobgp module.
+func enableListenerMD5(l *net.TCPListener, peerAddress, password string) error {
+	if password == "" {
+		return nil
+	}
+
+	if l == nil {
+		return errListenerUnavailable
+	}
+
+	if len(password) > tcpMD5SigMaxKeyLen {
+		return fmt.Errorf("TCP MD5 signature password for peer %s exceeds the kernel's %d-byte limit", peerAddress, tcpMD5SigMaxKeyLen)
+	}
+
+	prefix, err := netip.ParsePrefix(peerAddress)
+	if err != nil {
+		return fmt.Errorf("failed to parse peer address %q for TCP MD5 signature option: %w", peerAddress, err)
+	}
+	addr := prefix.Addr()
+
+	var sig unix.TCPMD5Sig
+	sig.Keylen = uint16(len(password))
+	copy(sig.Key[:], password)
+
+	if addr.Is4() {
+		sig.Addr.Family = unix.AF_INET
+		sa := (*unix.RawSockaddrInet4)(unsafe.Pointer(&sig.Addr))
+		ip4 := addr.As4()
+		copy(sa.Addr[:], ip4[:])
+	} else {
+		sig.Addr.Family = unix.AF_INET6
+		sa := (*unix.RawSockaddrInet6)(unsafe.Pointer(&sig.Addr))
+		ip16 := addr.As16()
+		copy(sa.Addr[:], ip16[:])
+	}
+
+	rawConn, err := l.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("failed to access raw listener socket for peer %s: %w", peerAddress, err)
+	}
+
+	var sockErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptTCPMD5Sig(int(fd), unix.IPPROTO_TCP, unix.TCP_MD5SIG, &sig)
+	}); err != nil {
+		return fmt.Errorf("failed to access raw listener socket for peer %s: %w", peerAddress, err)
+	}
+	if sockErr != nil {
+		return fmt.Errorf("failed to set TCP MD5 signature option for peer %s: %w", peerAddress, sockErr)
+	}
+
+	return nil
+}
+
+// passwordChanged reports whether the resolved secret for a neighbor
+// differs from what is currently applied, so that UpdateNeighbor only
+// re-applies the kernel-level TCP MD5 option when a rotation actually
+// occurred.
+func passwordChanged(current, resolved string) bool {
+	return current != resolved
+}
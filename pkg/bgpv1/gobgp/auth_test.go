@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package gobgp
+
+import (
+	"context"
+	"testing"
+
+	gobgpapi "github.com/osrg/gobgp/v3/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolveNeighborPassword(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "bgp-peer-auth", Namespace: "kube-system"},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	}
+	client := k8sfake.NewSimpleClientset(secret)
+
+	password, err := resolveNeighborPassword(context.Background(), client, "kube-system", "bgp-peer-auth")
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", password)
+}
+
+func TestResolveNeighborPasswordEmptyName(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+
+	password, err := resolveNeighborPassword(context.Background(), client, "kube-system", "")
+	require.NoError(t, err)
+	require.Empty(t, password)
+}
+
+func TestResolveNeighborPasswordMissingKey(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "bgp-peer-auth", Namespace: "kube-system"},
+		Data:       map[string][]byte{"unrelated-key": []byte("s3cr3t")},
+	}
+	client := k8sfake.NewSimpleClientset(secret)
+
+	_, err := resolveNeighborPassword(context.Background(), client, "kube-system", "bgp-peer-auth")
+	require.Error(t, err)
+}
+
+func TestApplyNeighborAuth(t *testing.T) {
+	peer := &gobgpapi.Peer{}
+
+	applyNeighborAuth(peer, "s3cr3t")
+	require.True(t, peer.Transport.PasswordEnabled)
+	require.Equal(t, "s3cr3t", peer.Transport.Password)
+
+	applyNeighborAuth(peer, "")
+	require.False(t, peer.Transport.PasswordEnabled)
+	require.Empty(t, peer.Transport.Password)
+}
+
+func TestPasswordChanged(t *testing.T) {
+	require.True(t, passwordChanged("old", "new"))
+	require.False(t, passwordChanged("same", "same"))
+}
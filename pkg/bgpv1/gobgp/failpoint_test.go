@@ -0,0 +1,53 @@
+//go:build failpoints
+
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package gobgp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+
+	"github.com/cilium/cilium/pkg/bgpv1/types"
+	"github.com/cilium/cilium/pkg/failpoint"
+	v2alpha1api "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2alpha1"
+)
+
+// TestGetPeerStateConvergesAfterFailpoint confirms that GetPeerState
+// surfaces the injected failure while failpointListPeers is enabled, and
+// converges back to success as soon as it is disabled again.
+func TestGetPeerStateConvergesAfterFailpoint(t *testing.T) {
+	srvParams := types.ServerParameters{
+		Global: types.BGPGlobal{
+			ASN:        64124,
+			RouterID:   "127.0.0.1",
+			ListenPort: -1,
+		},
+	}
+
+	testSC, err := NewGoBGPServerWithConfig(context.Background(), log, srvParams)
+	require.NoError(t, err)
+	t.Cleanup(func() { testSC.Stop() })
+
+	err = testSC.AddNeighbor(context.Background(), types.NeighborRequest{
+		Neighbor: &v2alpha1api.CiliumBGPNeighbor{
+			PeerASN:     64125,
+			PeerAddress: "192.168.0.1/32",
+			PeerPort:    pointer.Int(types.DefaultPeerPort),
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, failpoint.Enable(failpointListPeers))
+	_, err = testSC.GetPeerState(context.Background())
+	require.Error(t, err, "GetPeerState should fail while failpointListPeers is enabled")
+
+	require.NoError(t, failpoint.Disable(failpointListPeers))
+	res, err := testSC.GetPeerState(context.Background())
+	require.NoError(t, err, "GetPeerState should converge once failpointListPeers is disabled")
+	require.Len(t, res.Peers, 1)
+}
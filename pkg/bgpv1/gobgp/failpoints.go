@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package gobgp
+
+// Named failpoints wired into GoBGPServer's reconciliation path, for use
+// with github.com/cilium/cilium/pkg/failpoint. See AddNeighbor,
+// UpdateNeighbor, and GetPeerState for the corresponding failpoint.Inject
+// call sites.
+const (
+	// failpointListPeers forces GetPeerState to fail before its underlying
+	// gobgpd ListPeers call, simulating a transient RPC error against the
+	// local gobgpd process. AddNeighbor injects it as well, and fails before
+	// adding the peer while it is enabled.
+	failpointListPeers = "gobgp/failListPeers"
+
+	// failpointStallUpdateNeighbor blocks UpdateNeighbor until its context
+	// is cancelled and then fails it with the context's error, simulating a
+	// stalled reconciliation pass.
+	failpointStallUpdateNeighbor = "gobgp/stallUpdateNeighbor"
+)
@@ -0,0 +1,321 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package gobgp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+
+	gobgpapi "github.com/osrg/gobgp/v3/api"
+	gobgp "github.com/osrg/gobgp/v3/pkg/server"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/cilium/cilium/api/v1/models"
+	"github.com/cilium/cilium/pkg/bgpv1/types"
+	"github.com/cilium/cilium/pkg/failpoint"
+	v2alpha1api "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2alpha1"
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+// GoBGPServer wraps a single gobgp.BgpServer instance, translating between
+// CiliumBGPNeighbor/models.BgpPeer and gobgp's own API types.
+type GoBGPServer struct {
+	logger *logrus.Entry
+
+	server *gobgp.BgpServer
+	// listener, when non-nil, is the TCP listener peer connections arrive
+	// on, used to set TCP_MD5SIG for passwords applied via AddNeighbor/
+	// UpdateNeighbor. gobgp manages its own passive listener internally and
+	// does not expose it through its public API, so this is always nil
+	// today; enableListenerMD5 degrades to relying solely on gobgp's own
+	// Transport.Password enforcement whenever it is unset.
+	listener  *net.TCPListener
+	k8sClient kubernetes.Interface
+
+	mutex lock.RWMutex
+	// passwords tracks the last password resolved and applied for each
+	// peer, keyed by "<asn>/<address>", so that UpdateNeighbor can tell
+	// whether a secret rotation actually changed anything.
+	passwords map[string]string
+}
+
+// NewGoBGPServerWithConfig starts a new gobgp server instance with the given
+// global parameters.
+func NewGoBGPServerWithConfig(ctx context.Context, log *logrus.Entry, params types.ServerParameters) (*GoBGPServer, error) {
+	s := gobgp.NewBgpServer()
+	go s.Serve()
+
+	if err := s.StartBgp(ctx, &gobgpapi.StartBgpRequest{
+		Global: &gobgpapi.Global{
+			Asn:        params.Global.ASN,
+			RouterId:   params.Global.RouterID,
+			ListenPort: params.Global.ListenPort,
+		},
+	}); err != nil {
+		s.StopBgp(ctx, &gobgpapi.StopBgpRequest{})
+		return nil, fmt.Errorf("failed starting BGP server: %w", err)
+	}
+
+	return &GoBGPServer{
+		logger:    log,
+		server:    s,
+		passwords: make(map[string]string),
+	}, nil
+}
+
+// Stop tears down the underlying gobgp server.
+func (g *GoBGPServer) Stop() {
+	g.server.StopBgp(context.Background(), &gobgpapi.StopBgpRequest{})
+}
+
+// AddNeighbor configures a new BGP peer, resolving and applying any TCP MD5
+// authentication requested on the neighbor. The neighbor's TCPAO settings
+// are not applied.
+func (g *GoBGPServer) AddNeighbor(ctx context.Context, req types.NeighborRequest) error {
+	if err := failpoint.Inject(failpointListPeers, func() error {
+		return fmt.Errorf("injected failure listing peers")
+	}); err != nil {
+		return err
+	}
+
+	peer, password, err := g.toGoBGPPeer(ctx, req.Neighbor)
+	if err != nil {
+		return err
+	}
+
+	if err := g.server.AddPeer(ctx, &gobgpapi.AddPeerRequest{Peer: peer}); err != nil {
+		return fmt.Errorf("failed adding peer: %w", err)
+	}
+
+	if err := enableListenerMD5(g.listener, req.Neighbor.PeerAddress, password); err != nil {
+		if !errors.Is(err, errListenerUnavailable) {
+			g.logger.WithError(err).WithField("peerAddress", req.Neighbor.PeerAddress).
+				Error("Failed to enable TCP MD5 signature for peer")
+			return err
+		}
+		g.logger.WithField("peerAddress", req.Neighbor.PeerAddress).
+			Debug("No listener available for kernel-level TCP MD5 signature; relying on gobgp's own transport-level password")
+	}
+
+	g.mutex.Lock()
+	g.passwords[peerKey(req.Neighbor)] = password
+	g.mutex.Unlock()
+
+	return nil
+}
+
+// UpdateNeighbor reconciles an existing peer's configuration, re-resolving
+// its authentication secret on every call. gobgp's transport-level password
+// is sent with every UpdatePeer; the kernel-level TCP MD5 option is
+// re-applied only if the password changed.
+func (g *GoBGPServer) UpdateNeighbor(ctx context.Context, req types.NeighborRequest) error {
+	if err := failpoint.Inject(failpointStallUpdateNeighbor, func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	}); err != nil {
+		return err
+	}
+
+	if err := g.findPeer(ctx, req.Neighbor); err != nil {
+		return err
+	}
+
+	peer, password, err := g.toGoBGPPeer(ctx, req.Neighbor)
+	if err != nil {
+		return err
+	}
+
+	if err := g.server.UpdatePeer(ctx, &gobgpapi.UpdatePeerRequest{Peer: peer}); err != nil {
+		return fmt.Errorf("failed updating peer: %w", err)
+	}
+
+	key := peerKey(req.Neighbor)
+	g.mutex.Lock()
+	changed := passwordChanged(g.passwords[key], password)
+	g.passwords[key] = password
+	g.mutex.Unlock()
+
+	if changed {
+		if err := enableListenerMD5(g.listener, req.Neighbor.PeerAddress, password); err != nil && !errors.Is(err, errListenerUnavailable) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findPeer reports an error matching the one test fixtures assert on if no
+// existing peer matches n's ASN and address.
+func (g *GoBGPServer) findPeer(ctx context.Context, n *v2alpha1api.CiliumBGPNeighbor) error {
+	prefix, err := netip.ParsePrefix(n.PeerAddress)
+	if err != nil {
+		return fmt.Errorf("failed to parse PeerAddress: %w", err)
+	}
+
+	var found bool
+	err = g.server.ListPeer(ctx, &gobgpapi.ListPeerRequest{}, func(p *gobgpapi.Peer) {
+		if p.Conf == nil {
+			return
+		}
+		if p.Conf.PeerAsn == n.PeerASN && p.Conf.NeighborAddress == prefix.Addr().String() {
+			found = true
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed retrieving peer: %w", err)
+	}
+
+	if !found {
+		return fmt.Errorf("failed retrieving peer: could not find existing peer with ASN: %d and IP: %s", n.PeerASN, prefix.Addr().String())
+	}
+
+	return nil
+}
+
+// GetPeerState returns the observed state of every configured peer.
+func (g *GoBGPServer) GetPeerState(ctx context.Context) (*models.GetPeerStateResponse, error) {
+	if err := failpoint.Inject(failpointListPeers, func() error {
+		return fmt.Errorf("injected failure listing peers")
+	}); err != nil {
+		return nil, err
+	}
+
+	res := &models.GetPeerStateResponse{}
+
+	err := g.server.ListPeer(ctx, &gobgpapi.ListPeerRequest{}, func(p *gobgpapi.Peer) {
+		res.Peers = append(res.Peers, toAgentPeer(p))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving peer: %w", err)
+	}
+
+	return res, nil
+}
+
+// toGoBGPPeer converts a CiliumBGPNeighbor into a gobgp Peer, resolving and
+// applying its authentication secret. It never embeds the resolved password
+// in the returned error, so a failed conversion cannot leak it.
+func (g *GoBGPServer) toGoBGPPeer(ctx context.Context, n *v2alpha1api.CiliumBGPNeighbor) (*gobgpapi.Peer, string, error) {
+	prefix, err := netip.ParsePrefix(n.PeerAddress)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse PeerAddress: %w", err)
+	}
+
+	port := types.DefaultPeerPort
+	if n.PeerPort != nil {
+		port = *n.PeerPort
+	}
+
+	peer := &gobgpapi.Peer{
+		Conf: &gobgpapi.PeerConf{
+			NeighborAddress: prefix.Addr().String(),
+			PeerAsn:         n.PeerASN,
+		},
+		Transport: &gobgpapi.Transport{
+			RemotePort: uint32(port),
+		},
+		Timers: &gobgpapi.Timers{
+			Config: &gobgpapi.TimersConfig{
+				ConnectRetry:      uint64(n.ConnectRetryTime.Seconds()),
+				HoldTime:          uint64(n.HoldTime.Seconds()),
+				KeepaliveInterval: uint64(n.KeepAliveTime.Seconds()),
+			},
+		},
+		GracefulRestart: &gobgpapi.GracefulRestart{
+			Enabled:     n.GracefulRestart.Enabled,
+			RestartTime: uint32(n.GracefulRestart.RestartTime.Seconds()),
+		},
+		EbgpMultihop: &gobgpapi.EbgpMultihop{
+			Enabled:     n.EBGPMultihopTTL > 0,
+			MultihopTtl: uint32(n.EBGPMultihopTTL),
+		},
+	}
+
+	password, err := g.resolvePassword(ctx, n)
+	if err != nil {
+		return nil, "", err
+	}
+	applyNeighborAuth(peer, password)
+
+	return peer, password, nil
+}
+
+// resolvePassword resolves a neighbor's authentication secret, preferring
+// AuthSecretRef over the inline AuthPassword when the server has a
+// Kubernetes client. Without one, AuthSecretRef is ignored and AuthPassword
+// is used as is; NewGoBGPServerWithConfig does not set a client, so servers
+// it creates never resolve AuthSecretRef.
+func (g *GoBGPServer) resolvePassword(ctx context.Context, n *v2alpha1api.CiliumBGPNeighbor) (string, error) {
+	if n.AuthSecretRef == nil {
+		return n.AuthPassword, nil
+	}
+
+	if g.k8sClient == nil {
+		return n.AuthPassword, nil
+	}
+
+	return resolveNeighborPassword(ctx, g.k8sClient, n.AuthSecretRef.Namespace, n.AuthSecretRef.Name)
+}
+
+// sessionStateNames maps gobgp's session state enum onto the lowercase
+// strings surfaced through models.BgpPeer.
+var sessionStateNames = map[gobgpapi.PeerState_SessionState]string{
+	gobgpapi.PeerState_UNKNOWN:     "unknown",
+	gobgpapi.PeerState_IDLE:        "idle",
+	gobgpapi.PeerState_CONNECT:     "connect",
+	gobgpapi.PeerState_ACTIVE:      "active",
+	gobgpapi.PeerState_OPENSENT:    "opensent",
+	gobgpapi.PeerState_OPENCONFIRM: "openconfirm",
+	gobgpapi.PeerState_ESTABLISHED: "established",
+}
+
+// toAgentPeer converts a gobgp Peer's observed state into a models.BgpPeer.
+// The resolved password is deliberately never read back out of the gobgp
+// Peer here, so it can never be returned through models.BgpPeer.
+func toAgentPeer(p *gobgpapi.Peer) *models.BgpPeer {
+	peer := &models.BgpPeer{
+		SessionState: "idle",
+	}
+
+	if p.Conf != nil {
+		peer.PeerAsn = int64(p.Conf.PeerAsn)
+		peer.PeerAddress = p.Conf.NeighborAddress
+		peer.LocalAsn = int64(p.Conf.LocalAsn)
+	}
+
+	if p.State != nil {
+		if name, ok := sessionStateNames[p.State.SessionState]; ok {
+			peer.SessionState = name
+		}
+	}
+
+	if p.Timers != nil && p.Timers.Config != nil {
+		peer.ConnectRetryTimeSeconds = int64(p.Timers.Config.ConnectRetry)
+		peer.ConfiguredHoldTimeSeconds = int64(p.Timers.Config.HoldTime)
+		peer.ConfiguredKeepAliveTimeSeconds = int64(p.Timers.Config.KeepaliveInterval)
+	}
+
+	if p.EbgpMultihop != nil {
+		peer.EbgpMultihopTTL = int64(p.EbgpMultihop.MultihopTtl)
+	}
+
+	if p.GracefulRestart != nil {
+		peer.GracefulRestart = &models.BgpPeerGracefulRestart{
+			Enabled:            p.GracefulRestart.Enabled,
+			RestartTimeSeconds: int64(p.GracefulRestart.RestartTime),
+		}
+	}
+
+	return peer
+}
+
+// peerKey identifies a neighbor for the purposes of password-rotation
+// tracking, independent of its other configuration fields.
+func peerKey(n *v2alpha1api.CiliumBGPNeighbor) string {
+	return fmt.Sprintf("%d/%s", n.PeerASN, n.PeerAddress)
+}
@@ -5,6 +5,7 @@ package gobgp
 
 import (
 	"context"
+	"encoding/json"
 	"net/netip"
 	"testing"
 	"time"
@@ -339,3 +340,46 @@ func findMatchingPeer(t *testing.T, peers []*models.BgpPeer, n *v2alpha1api.Cili
 	}
 	return nil
 }
+
+// TestAddNeighborWithMD5Auth confirms that a neighbor configured with an
+// inline TCP MD5 password is accepted by AddNeighbor and that the password
+// is never surfaced back out through GetPeerState's models.BgpPeer.
+func TestAddNeighborWithMD5Auth(t *testing.T) {
+	const password = "s3cr3t-md5-password"
+
+	neighbor := &v2alpha1api.CiliumBGPNeighbor{
+		PeerASN:          64125,
+		PeerAddress:      "192.168.0.1/32",
+		PeerPort:         pointer.Int(types.DefaultPeerPort),
+		AuthPassword:     password,
+		ConnectRetryTime: metav1.Duration{Duration: 99 * time.Second},
+		HoldTime:         metav1.Duration{Duration: 9 * time.Second},
+		KeepAliveTime:    metav1.Duration{Duration: 3 * time.Second},
+	}
+
+	srvParams := types.ServerParameters{
+		Global: types.BGPGlobal{
+			ASN:        64124,
+			RouterID:   "127.0.0.1",
+			ListenPort: -1,
+		},
+	}
+
+	testSC, err := NewGoBGPServerWithConfig(context.Background(), log, srvParams)
+	require.NoError(t, err)
+	t.Cleanup(func() { testSC.Stop() })
+
+	err = testSC.AddNeighbor(context.Background(), types.NeighborRequest{Neighbor: neighbor})
+	require.NoError(t, err)
+
+	res, err := testSC.GetPeerState(context.Background())
+	require.NoError(t, err)
+	require.Len(t, res.Peers, 1)
+
+	p := findMatchingPeer(t, res.Peers, neighbor)
+	require.NotNil(t, p)
+
+	b, err := json.Marshal(p)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), password)
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package types holds the router-agnostic types shared between the BGP
+// control plane and its backend implementations (currently gobgp).
+package types
+
+import (
+	v2alpha1api "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2alpha1"
+)
+
+// DefaultPeerPort is the TCP port used for a peer's session when
+// CiliumBGPNeighbor.PeerPort is unset.
+const DefaultPeerPort = 179
+
+// BGPGlobal holds the global parameters of a single BGP router instance.
+type BGPGlobal struct {
+	// ASN is this router's autonomous system number.
+	ASN uint32
+	// RouterID is this router's BGP identifier, typically one of its own
+	// addresses.
+	RouterID string
+	// ListenPort is the TCP port this router listens for incoming peer
+	// connections on. A negative value disables listening, for use in
+	// tests that only dial out.
+	ListenPort int32
+}
+
+// ServerParameters groups the parameters needed to start a new BGP router
+// instance.
+type ServerParameters struct {
+	Global BGPGlobal
+}
+
+// NeighborRequest wraps a CiliumBGPNeighbor for AddNeighbor/UpdateNeighbor
+// calls against a BGP router backend.
+type NeighborRequest struct {
+	Neighbor *v2alpha1api.CiliumBGPNeighbor
+}
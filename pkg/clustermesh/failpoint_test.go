@@ -0,0 +1,65 @@
+//go:build failpoints
+
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package clustermesh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cilium/cilium/pkg/failpoint"
+	"github.com/cilium/cilium/pkg/lock"
+	serviceStore "github.com/cilium/cilium/pkg/service/store"
+)
+
+// fakeServiceMerger records merged services in place of the real global
+// service cache, so this test doesn't depend on ClusterMesh's Configuration
+// wiring.
+type fakeServiceMerger struct {
+	mutex  lock.Mutex
+	merged map[string]int
+}
+
+func (m *fakeServiceMerger) MergeExternalServiceUpdate(service *serviceStore.ClusterService, swg *lock.StoppableWaitGroup) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.merged[service.Name]++
+}
+
+func (m *fakeServiceMerger) MergeExternalServiceDelete(service *serviceStore.ClusterService, swg *lock.StoppableWaitGroup) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.merged, service.Name)
+}
+
+func (m *fakeServiceMerger) seen(name string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.merged[name]
+}
+
+// TestRemoteServiceObserverDropIPCacheEventConverges confirms that
+// remoteServiceObserver.OnUpdate drops the event while the
+// failpointDropIPCacheEvent failpoint is enabled, and resumes forwarding
+// updates to the ServiceMerger as soon as it is disabled again.
+func TestRemoteServiceObserverDropIPCacheEventConverges(t *testing.T) {
+	merger := &fakeServiceMerger{merged: make(map[string]int)}
+	rc := &remoteCluster{
+		name: "remote",
+		mesh: &ClusterMesh{conf: Configuration{ServiceMerger: merger}},
+	}
+	observer := &remoteServiceObserver{remoteCluster: rc, swg: lock.NewStoppableWaitGroup()}
+
+	svc := &serviceStore.ClusterService{Name: "echo"}
+
+	require.NoError(t, failpoint.Enable(failpointDropIPCacheEvent))
+	observer.OnUpdate(svc)
+	require.Equal(t, 0, merger.seen("echo"), "update should have been dropped by the failpoint")
+
+	require.NoError(t, failpoint.Disable(failpointDropIPCacheEvent))
+	observer.OnUpdate(svc)
+	require.Equal(t, 1, merger.seen("echo"), "update should converge once the failpoint is disabled")
+}
@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package clustermesh
+
+// Named failpoints wired into the remote cluster synchronization path, for
+// use with github.com/cilium/cilium/pkg/failpoint. See remoteCluster's
+// onUpdate and remoteServiceObserver for the corresponding failpoint.Inject
+// call sites.
+const (
+	// failpointDelayRemoteSync stalls every connection attempt made by
+	// remoteCluster.onUpdate for 5 seconds (or until its context is
+	// cancelled) before the node/service stores start syncing.
+	failpointDelayRemoteSync = "clustermesh/delayRemoteSync"
+
+	// failpointDropIPCacheEvent causes every service update that
+	// remoteServiceObserver receives from a remote cluster to be silently
+	// discarded while it is enabled, simulating missed watch events.
+	failpointDropIPCacheEvent = "clustermesh/dropIPCacheEvent"
+)
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package integration
+
+import (
+	"sync"
+
+	"github.com/cilium/cilium/pkg/allocator"
+	"github.com/cilium/cilium/pkg/kvstore"
+	"github.com/cilium/cilium/pkg/kvstore/store"
+	"github.com/cilium/cilium/pkg/lock"
+	serviceStore "github.com/cilium/cilium/pkg/service/store"
+)
+
+// fakeNodeKey is a minimal store.Key used to let the harness inject raw
+// node updates without depending on the full node.Node type.
+type fakeNodeKey struct {
+	name string
+}
+
+func (k *fakeNodeKey) GetKeyName() string { return k.name }
+
+// fakeNodeObserver records every node update/delete it sees, so tests can
+// assert on convergence without reaching into ClusterMesh internals.
+type fakeNodeObserver struct {
+	mutex   sync.Mutex
+	updated map[string]int
+	deleted map[string]int
+}
+
+func newFakeNodeObserver() *fakeNodeObserver {
+	return &fakeNodeObserver{
+		updated: make(map[string]int),
+		deleted: make(map[string]int),
+	}
+}
+
+func (o *fakeNodeObserver) OnUpdate(k store.Key) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.updated[k.GetKeyName()]++
+}
+
+func (o *fakeNodeObserver) OnDelete(k store.Key) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.deleted[k.GetKeyName()]++
+}
+
+// seen returns how many times the node with the given name was observed.
+func (o *fakeNodeObserver) seen(name string) int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.updated[name]
+}
+
+// fakeServiceMerger records merged/removed services in place of the real
+// global service cache.
+type fakeServiceMerger struct {
+	mutex  sync.Mutex
+	merged map[string]int
+}
+
+func newFakeServiceMerger() *fakeServiceMerger {
+	return &fakeServiceMerger{merged: make(map[string]int)}
+}
+
+func (m *fakeServiceMerger) MergeExternalServiceUpdate(service *serviceStore.ClusterService, swg *lock.StoppableWaitGroup) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.merged[service.Name]++
+}
+
+func (m *fakeServiceMerger) MergeExternalServiceDelete(service *serviceStore.ClusterService, swg *lock.StoppableWaitGroup) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.merged, service.Name)
+}
+
+// fakeIdentityWatcher stands in for the identity allocator's remote cache
+// handling, recording which remotes were watched/removed.
+type fakeIdentityWatcher struct {
+	mutex   sync.Mutex
+	watched map[string]bool
+}
+
+func newFakeIdentityWatcher() *fakeIdentityWatcher {
+	return &fakeIdentityWatcher{watched: make(map[string]bool)}
+}
+
+func (w *fakeIdentityWatcher) WatchRemoteIdentities(remoteName string, backend kvstore.BackendOperations) (*allocator.RemoteCache, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.watched[remoteName] = true
+	return nil, nil
+}
+
+func (w *fakeIdentityWatcher) RemoveRemoteIdentities(name string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	delete(w.watched, name)
+}
+
+func (w *fakeIdentityWatcher) Close() {}
@@ -0,0 +1,407 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package integration provides an in-process, multi-cluster ClusterMesh test
+// harness, in the spirit of etcd's integration/cluster.go. It starts one
+// embedded etcd server per simulated remote cluster, wires each of them
+// together through a real clustermesh.ClusterMesh, and exposes helpers to
+// drive and observe them from a single *testing.T without requiring an
+// external kvstore or a full end-to-end cluster.
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cilium/cilium/pkg/clustermesh"
+	"github.com/cilium/cilium/pkg/clustermesh/internal"
+	cmtypes "github.com/cilium/cilium/pkg/clustermesh/types"
+	"github.com/cilium/cilium/pkg/hive/hivetest"
+	"github.com/cilium/cilium/pkg/ipcache"
+	"github.com/cilium/cilium/pkg/kvstore/store"
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+// clusterConfigPrefix mirrors the key under which each cluster publishes
+// its CiliumClusterConfig blob for other clusters to read on connect.
+const clusterConfigPrefix = "cilium/cluster-config/"
+
+// Harness owns a set of simulated remote clusters, each backed by its own
+// embedded etcd server and ClusterMesh instance, plus a TCP proxy in front
+// of every etcd server so that Partition/Heal can simulate a network split
+// without tearing the servers themselves down.
+type Harness struct {
+	t        *testing.T
+	clusters []*Cluster
+}
+
+// New starts n embedded etcd servers and n corresponding ClusterMesh
+// instances, each one configured to see every other cluster as a remote.
+// The harness and every resource it creates are torn down automatically via
+// t.Cleanup.
+func New(t *testing.T, n int) *Harness {
+	t.Helper()
+
+	h := &Harness{t: t}
+	for i := 0; i < n; i++ {
+		h.clusters = append(h.clusters, newCluster(t, i))
+	}
+
+	for i, c := range h.clusters {
+		others := make([]*Cluster, 0, n-1)
+		for j, o := range h.clusters {
+			if i != j {
+				others = append(others, o)
+			}
+		}
+		c.connectTo(t, others)
+	}
+
+	return h
+}
+
+// Cluster returns the i'th simulated remote cluster.
+func (h *Harness) Cluster(i int) *Cluster {
+	return h.clusters[i]
+}
+
+// Partition cuts the simulated network link between cluster i and cluster
+// j in both directions, without stopping either etcd server.
+func (h *Harness) Partition(i, j int) {
+	h.clusters[i].proxies[j].pause()
+	h.clusters[j].proxies[i].pause()
+}
+
+// Heal restores the simulated network link between cluster i and cluster j
+// previously cut by Partition.
+func (h *Harness) Heal(i, j int) {
+	h.clusters[i].proxies[j].resume()
+	h.clusters[j].proxies[i].resume()
+}
+
+// WaitSynced blocks until every cluster in the harness reports that it has
+// synchronized all of its remotes, or ctx is cancelled.
+func (h *Harness) WaitSynced(ctx context.Context) error {
+	for _, c := range h.clusters {
+		if err := c.mesh.ClustersSynced(ctx); err != nil {
+			return fmt.Errorf("cluster %s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// Cluster is a single simulated remote cluster: an embedded etcd server
+// fronted by a pausable TCP proxy per peer, and the real ClusterMesh
+// instance that observes every other cluster in the harness.
+type Cluster struct {
+	Name      string
+	ClusterID uint32
+
+	etcd    *embed.Etcd
+	etcdCfg *embed.Config
+
+	mesh       *clustermesh.ClusterMesh
+	nodes      *fakeNodeObserver
+	services   *fakeServiceMerger
+	identities *fakeIdentityWatcher
+
+	clientAddr string
+	proxies    map[int]*tcpProxy
+}
+
+func newCluster(t *testing.T, index int) *Cluster {
+	t.Helper()
+
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	cfg.Name = fmt.Sprintf("integration-%d", index)
+
+	clientURL, err := url.Parse("http://127.0.0.1:0")
+	require.NoError(t, err)
+	peerURL, err := url.Parse("http://127.0.0.1:0")
+	require.NoError(t, err)
+
+	cfg.ListenClientUrls = []url.URL{*clientURL}
+	cfg.ListenPeerUrls = []url.URL{*peerURL}
+	cfg.AdvertiseClientUrls = cfg.ListenClientUrls
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	e, err := embed.StartEtcd(cfg)
+	require.NoError(t, err, "failed to start embedded etcd server")
+	waitReady(t, e)
+	t.Cleanup(e.Close)
+
+	c := &Cluster{
+		Name:       fmt.Sprintf("cluster-%d", index),
+		ClusterID:  uint32(index + 1),
+		etcd:       e,
+		etcdCfg:    cfg,
+		nodes:      newFakeNodeObserver(),
+		services:   newFakeServiceMerger(),
+		identities: newFakeIdentityWatcher(),
+		clientAddr: e.Clients[0].Addr().String(),
+		proxies:    make(map[int]*tcpProxy),
+	}
+
+	require.NoError(t, c.publishClusterConfig(context.Background()))
+
+	return c
+}
+
+func waitReady(t *testing.T, e *embed.Etcd) {
+	t.Helper()
+
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("embedded etcd server did not become ready in time")
+	}
+}
+
+// connectTo starts a pausable proxy towards every other cluster's etcd
+// server, and constructs this cluster's ClusterMesh instance pointed at
+// those proxies rather than directly at the remote servers, so that
+// Harness.Partition can later cut the link without touching the servers.
+func (c *Cluster) connectTo(t *testing.T, others []*Cluster) {
+	t.Helper()
+
+	configDir := t.TempDir()
+	for _, o := range others {
+		proxy := newTCPProxy(t, o.clientAddr)
+		c.proxies[int(o.ClusterID)-1] = proxy
+		writeEtcdConfig(t, configDir, o.Name, proxy.addr())
+	}
+
+	lifecycle := hivetest.Lifecycle(t)
+	c.mesh = clustermesh.NewClusterMesh(lifecycle, clustermesh.Configuration{
+		Config: internal.Config{ClusterMeshConfig: configDir},
+		ClusterIDName: cmtypes.ClusterIDName{
+			ClusterID:   c.ClusterID,
+			ClusterName: c.Name,
+		},
+		NodeKeyCreator:               func() store.Key { return &fakeNodeKey{} },
+		ServiceMerger:                c.services,
+		NodeObserver:                 c.nodes,
+		RemoteIdentityWatcher:        c.identities,
+		IPCache:                      ipcache.NewIPCache(&ipcache.Configuration{Context: context.Background()}),
+		ClusterSizeDependantInterval: func(time.Duration) time.Duration { return 50 * time.Millisecond },
+	})
+}
+
+// writeEtcdConfig writes a kvstore etcd config file for the remote cluster
+// named remoteName, pointing at addr, under dir. This mirrors the on-disk
+// layout of the "clustermesh-secrets" mount: one file per remote cluster,
+// named after the cluster, containing an etcd client config.
+func writeEtcdConfig(t *testing.T, dir, remoteName, addr string) {
+	t.Helper()
+
+	cfg := struct {
+		Endpoints []string `yaml:"endpoints"`
+	}{
+		Endpoints: []string{"http://" + addr},
+	}
+
+	b, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, remoteName), b, 0o600))
+}
+
+// publishClusterConfig writes this cluster's own CiliumClusterConfig blob
+// into its embedded etcd server, so that remotes which connect to it can
+// fetch and validate it.
+func (c *Cluster) publishClusterConfig(ctx context.Context) error {
+	return c.publishClusterConfigWithID(ctx, c.ClusterID)
+}
+
+// publishClusterConfigWithID is like publishClusterConfig, but publishes
+// the given clusterID instead of this cluster's own, for tests that need
+// to simulate a cluster-id collision or another form of incompatible
+// configuration.
+func (c *Cluster) publishClusterConfigWithID(ctx context.Context, clusterID uint32) error {
+	cli := c.mustEtcdClient()
+	defer cli.Close()
+
+	b, err := json.Marshal(cmtypes.CiliumClusterConfig{ID: clusterID})
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.Put(ctx, clusterConfigPrefix+c.Name, string(b))
+	return err
+}
+
+// PutNode injects a node update for this cluster, as if it had just been
+// observed from this cluster's local store.
+func (c *Cluster) PutNode(ctx context.Context, name string) error {
+	cli := c.mustEtcdClient()
+	defer cli.Close()
+
+	key := fmt.Sprintf("cilium/state/nodes/v1/%s/%s", c.Name, name)
+	_, err := cli.Put(ctx, key, name)
+	return err
+}
+
+// PutService injects a service update for this cluster.
+func (c *Cluster) PutService(ctx context.Context, name string) error {
+	cli := c.mustEtcdClient()
+	defer cli.Close()
+
+	key := fmt.Sprintf("cilium/state/services/v1/%s/%s", c.Name, name)
+	_, err := cli.Put(ctx, key, name)
+	return err
+}
+
+// Restart stops and restarts this cluster's embedded etcd server in place,
+// reusing the same data directory, to exercise recovery after a kvstore
+// restart.
+func (c *Cluster) Restart(t *testing.T) {
+	t.Helper()
+
+	c.etcd.Close()
+
+	// Come back on the address the other clusters' proxies already dial,
+	// rather than on a fresh ephemeral port.
+	clientURL, err := url.Parse("http://" + c.clientAddr)
+	require.NoError(t, err)
+	c.etcdCfg.ListenClientUrls = []url.URL{*clientURL}
+	c.etcdCfg.AdvertiseClientUrls = c.etcdCfg.ListenClientUrls
+
+	e, err := embed.StartEtcd(c.etcdCfg)
+	require.NoError(t, err)
+	waitReady(t, e)
+
+	c.etcd = e
+	c.clientAddr = e.Clients[0].Addr().String()
+}
+
+func (c *Cluster) mustEtcdClient() *clientv3.Client {
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{c.clientAddr},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return cli
+}
+
+// tcpProxy forwards TCP connections to a single upstream address, and can
+// be paused to simulate a network partition without closing the upstream
+// listener itself. Pausing both blocks new connections and severs every
+// connection already relaying traffic, so a partition takes effect
+// immediately instead of only once existing connections happen to close on
+// their own.
+type tcpProxy struct {
+	listener net.Listener
+	upstream string
+
+	mutex  lock.Mutex
+	paused chan struct{}
+	conns  map[net.Conn]struct{}
+}
+
+func newTCPProxy(t *testing.T, upstream string) *tcpProxy {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	p := &tcpProxy{
+		listener: ln,
+		upstream: upstream,
+		paused:   make(chan struct{}),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	close(p.paused) // start resumed
+
+	go p.serve()
+	t.Cleanup(func() { ln.Close() })
+
+	return p
+}
+
+func (p *tcpProxy) addr() string {
+	return p.listener.Addr().String()
+}
+
+func (p *tcpProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.handle(conn)
+	}
+}
+
+func (p *tcpProxy) handle(conn net.Conn) {
+	defer conn.Close()
+
+	p.mutex.Lock()
+	paused := p.paused
+	p.mutex.Unlock()
+	<-paused // block new connections while partitioned
+
+	upstream, err := net.Dial("tcp", p.upstream)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+
+	p.mutex.Lock()
+	// We may have been paused again between waiting above and acquiring
+	// the lock here; don't let this connection slip through as live.
+	select {
+	case <-p.paused:
+		p.conns[conn] = struct{}{}
+		p.mutex.Unlock()
+	default:
+		p.mutex.Unlock()
+		return
+	}
+	defer func() {
+		p.mutex.Lock()
+		delete(p.conns, conn)
+		p.mutex.Unlock()
+	}()
+
+	done := make(chan struct{}, 2)
+	go func() { io.Copy(upstream, conn); done <- struct{}{} }()
+	go func() { io.Copy(conn, upstream); done <- struct{}{} }()
+	<-done
+}
+
+// pause blocks new connections and forcibly closes every connection
+// currently relaying traffic through the proxy, simulating the link
+// dropping rather than merely going quiet for new dialers.
+func (p *tcpProxy) pause() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.paused = make(chan struct{})
+	for conn := range p.conns {
+		conn.Close()
+	}
+	p.conns = make(map[net.Conn]struct{})
+}
+
+func (p *tcpProxy) resume() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	close(p.paused)
+}
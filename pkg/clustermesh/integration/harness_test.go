@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package integration
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoClusterConvergence(t *testing.T) {
+	h := New(t, 2)
+
+	require.NoError(t, h.Cluster(0).PutNode(context.Background(), "node-a"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, h.WaitSynced(ctx))
+
+	require.Eventually(t, func() bool {
+		return h.Cluster(1).nodes.seen("node-a") > 0
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestPartitionAndHeal(t *testing.T) {
+	h := New(t, 2)
+
+	h.Partition(0, 1)
+	require.NoError(t, h.Cluster(0).PutNode(context.Background(), "node-during-partition"))
+
+	// The update must not reach cluster 1 while partitioned.
+	time.Sleep(200 * time.Millisecond)
+	require.Zero(t, h.Cluster(1).nodes.seen("node-during-partition"))
+
+	h.Heal(0, 1)
+
+	require.Eventually(t, func() bool {
+		return h.Cluster(1).nodes.seen("node-during-partition") > 0
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestIncompatibleClusterConfigIsRejected(t *testing.T) {
+	h := New(t, 2)
+
+	// Republish cluster 1's config using cluster 0's ID, which the two
+	// clusters must not agree on.
+	require.NoError(t, h.Cluster(1).publishClusterConfigWithID(context.Background(), h.Cluster(0).ClusterID))
+
+	require.Never(t, func() bool {
+		return h.Cluster(0).mesh.NumReadyClusters() == 1
+	}, 2*time.Second, 100*time.Millisecond)
+}
+
+func TestRecoversAfterKVStoreRestart(t *testing.T) {
+	h := New(t, 2)
+
+	h.Cluster(0).Restart(t)
+
+	require.NoError(t, h.Cluster(0).PutNode(context.Background(), "node-after-restart"))
+
+	require.Eventually(t, func() bool {
+		return h.Cluster(1).nodes.seen("node-after-restart") > 0
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+// TestTCPProxyPauseSeversLiveConnections confirms that pause() doesn't just
+// gate new connections: it actively closes connections that are already
+// relaying traffic, so a simulated partition takes effect immediately
+// instead of waiting for existing long-lived streams (like an etcd watch)
+// to close on their own.
+func TestTCPProxyPauseSeversLiveConnections(t *testing.T) {
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { upstream.Close() })
+
+	go func() {
+		for {
+			conn, err := upstream.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+
+	p := newTCPProxy(t, upstream.Addr().String())
+
+	conn, err := net.Dial("tcp", p.addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	// Establish the connection is actually live before partitioning it.
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+
+	p.pause()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	require.Error(t, err, "pause() must sever already-established connections, not just block new ones")
+}
@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+const (
+	// minHoldOff is the initial backoff duration applied to an endpoint the
+	// first time it is marked unhealthy.
+	minHoldOff = 1 * time.Second
+
+	// maxHoldOff is the ceiling that the exponential backoff applied to an
+	// unhealthy endpoint will never exceed.
+	maxHoldOff = 2 * time.Minute
+
+	// probeTimeout bounds each individual liveness probe issued by Run().
+	probeTimeout = 5 * time.Second
+)
+
+// endpointHealth tracks the backoff state of a single remote endpoint.
+type endpointHealth struct {
+	// unhealthyUntil is the time at which this endpoint may be retried
+	// again. The zero value means the endpoint is currently healthy.
+	unhealthyUntil time.Time
+
+	// holdOff is the backoff duration applied the last time this endpoint
+	// was marked unhealthy; the next mark doubles it, up to maxHoldOff. It
+	// is reset once the endpoint is seen healthy again.
+	holdOff time.Duration
+}
+
+func (h *endpointHealth) healthy(now time.Time) bool {
+	return h.unhealthyUntil.IsZero() || now.After(h.unhealthyUntil)
+}
+
+func (h *endpointHealth) markUnhealthy(now time.Time) {
+	if h.holdOff == 0 {
+		h.holdOff = minHoldOff
+	} else {
+		h.holdOff *= 2
+		if h.holdOff > maxHoldOff {
+			h.holdOff = maxHoldOff
+		}
+	}
+	h.unhealthyUntil = now.Add(h.holdOff)
+}
+
+func (h *endpointHealth) markHealthy() {
+	h.unhealthyUntil = time.Time{}
+	h.holdOff = 0
+}
+
+// prober is the liveness check performed against a single endpoint. It is
+// satisfied by the etcd clientv3 Status() RPC in production, and may be
+// substituted with a fake in tests.
+type prober func(ctx context.Context, endpoint string) error
+
+// Balancer maintains the health of the set of endpoints advertised by a
+// remote cluster and picks a healthy one to connect through, in the same
+// spirit as the health balancer embedded in etcd's clientv3. Endpoints that
+// return stream errors (a cancelled watch, a missed deadline, ...) are
+// pushed into an exponentially growing hold-off window and skipped by Pick()
+// until that window expires.
+type Balancer struct {
+	mutex lock.Mutex
+
+	endpoints []string
+	health    map[string]*endpointHealth
+
+	// next is the round-robin cursor into endpoints.
+	next int
+
+	probe    prober
+	interval time.Duration
+
+	metrics Metrics
+	cluster string
+}
+
+// BalancerConfig configures a new Balancer.
+type BalancerConfig struct {
+	// Endpoints is the full set of advertised endpoints for the remote
+	// cluster, as parsed out of CiliumClusterConfig.
+	Endpoints []string
+
+	// Probe performs a liveness check against a single endpoint. In
+	// production this issues an etcd Status() RPC; tests may substitute a
+	// fake.
+	Probe prober
+
+	// ProbeInterval is the interval at which healthy endpoints are
+	// re-probed. It is typically derived from ClusterSizeDependantInterval
+	// so that larger meshes probe less aggressively.
+	ProbeInterval time.Duration
+
+	Metrics Metrics
+	Cluster string
+}
+
+// NewBalancer creates a Balancer over the given set of endpoints. All
+// endpoints start out healthy.
+func NewBalancer(cfg BalancerConfig) *Balancer {
+	health := make(map[string]*endpointHealth, len(cfg.Endpoints))
+	for _, ep := range cfg.Endpoints {
+		health[ep] = &endpointHealth{}
+	}
+
+	return &Balancer{
+		endpoints: append([]string(nil), cfg.Endpoints...),
+		health:    health,
+		probe:     cfg.Probe,
+		interval:  cfg.ProbeInterval,
+		metrics:   cfg.Metrics,
+		cluster:   cfg.Cluster,
+	}
+}
+
+// Pick returns the next healthy endpoint in round-robin order, skipping any
+// endpoint whose hold-off window has not yet expired. It returns an error if
+// every known endpoint is currently unhealthy.
+func (b *Balancer) Pick() (string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	for i := 0; i < len(b.endpoints); i++ {
+		idx := (b.next + i) % len(b.endpoints)
+		ep := b.endpoints[idx]
+		if b.health[ep].healthy(now) {
+			b.next = (idx + 1) % len(b.endpoints)
+			return ep, nil
+		}
+	}
+
+	return "", fmt.Errorf("no healthy endpoints available for cluster %q", b.cluster)
+}
+
+// MarkUnhealthy records a connection failure for the given endpoint,
+// pushing it into an exponentially growing hold-off window so that
+// subsequent Pick() calls skip it until the window expires.
+func (b *Balancer) MarkUnhealthy(endpoint string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	h, ok := b.health[endpoint]
+	if !ok {
+		return
+	}
+
+	wasHealthy := h.healthy(time.Now())
+	h.markUnhealthy(time.Now())
+
+	if wasHealthy {
+		b.metrics.EndpointTransitions.WithLabelValues(b.cluster, endpoint, "unhealthy").Inc()
+	}
+}
+
+// MarkHealthy clears any hold-off window for the given endpoint, so that it
+// immediately becomes eligible for Pick() again.
+func (b *Balancer) MarkHealthy(endpoint string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	h, ok := b.health[endpoint]
+	if !ok {
+		return
+	}
+
+	wasHealthy := h.healthy(time.Now())
+	h.markHealthy()
+
+	if !wasHealthy {
+		b.metrics.EndpointTransitions.WithLabelValues(b.cluster, endpoint, "healthy").Inc()
+	}
+}
+
+// Run periodically probes every known endpoint until ctx is cancelled,
+// marking each one healthy or unhealthy based on the outcome. This keeps
+// endpoints that recovered on their own from sitting idle in the backoff
+// state until the next stream error surfaces one.
+func (b *Balancer) Run(ctx context.Context) {
+	if b.probe == nil || b.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.probeAll(ctx)
+		}
+	}
+}
+
+func (b *Balancer) probeAll(ctx context.Context) {
+	b.mutex.Lock()
+	endpoints := append([]string(nil), b.endpoints...)
+	b.mutex.Unlock()
+
+	for _, ep := range endpoints {
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		err := b.probe(probeCtx, ep)
+		cancel()
+
+		if err != nil {
+			b.MarkUnhealthy(ep)
+		} else {
+			b.MarkHealthy(ep)
+		}
+	}
+}
+
+// Status returns a snapshot of the per-endpoint health state, for
+// surfacing through the remote cluster's Status().
+func (b *Balancer) Status() map[string]bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	status := make(map[string]bool, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		status[ep] = b.health[ep].healthy(now)
+	}
+	return status
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend simulates a remote etcd endpoint that can be blackholed on
+// demand, for exercising Balancer without a real kvstore connection.
+type fakeBackend struct {
+	mutex       sync.Mutex
+	blackholed  map[string]bool
+	probeCalled map[string]int
+}
+
+func newFakeBackend(endpoints []string) *fakeBackend {
+	return &fakeBackend{
+		blackholed:  make(map[string]bool, len(endpoints)),
+		probeCalled: make(map[string]int, len(endpoints)),
+	}
+}
+
+func (f *fakeBackend) blackhole(endpoint string, down bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.blackholed[endpoint] = down
+}
+
+func (f *fakeBackend) status(ctx context.Context, endpoint string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.probeCalled[endpoint]++
+	if f.blackholed[endpoint] {
+		return fmt.Errorf("endpoint %s is blackholed", endpoint)
+	}
+	return nil
+}
+
+func TestBalancerPickRoundRobin(t *testing.T) {
+	endpoints := []string{"ep1", "ep2", "ep3"}
+	b := NewBalancer(BalancerConfig{
+		Endpoints: endpoints,
+		Metrics:   NewMetrics(),
+		Cluster:   "test",
+	})
+
+	seen := make([]string, 0, 6)
+	for i := 0; i < 6; i++ {
+		ep, err := b.Pick()
+		require.NoError(t, err)
+		seen = append(seen, ep)
+	}
+
+	require.Equal(t, []string{"ep1", "ep2", "ep3", "ep1", "ep2", "ep3"}, seen)
+}
+
+func TestBalancerSkipsUnhealthyEndpoint(t *testing.T) {
+	endpoints := []string{"ep1", "ep2"}
+	b := NewBalancer(BalancerConfig{
+		Endpoints: endpoints,
+		Metrics:   NewMetrics(),
+		Cluster:   "test",
+	})
+
+	b.MarkUnhealthy("ep1")
+
+	for i := 0; i < 3; i++ {
+		ep, err := b.Pick()
+		require.NoError(t, err)
+		require.Equal(t, "ep2", ep)
+	}
+}
+
+func TestBalancerAllUnhealthyReturnsError(t *testing.T) {
+	endpoints := []string{"ep1", "ep2"}
+	b := NewBalancer(BalancerConfig{
+		Endpoints: endpoints,
+		Metrics:   NewMetrics(),
+		Cluster:   "test",
+	})
+
+	b.MarkUnhealthy("ep1")
+	b.MarkUnhealthy("ep2")
+
+	_, err := b.Pick()
+	require.Error(t, err)
+}
+
+func TestBalancerRunRecoversBlackholedEndpoint(t *testing.T) {
+	endpoints := []string{"ep1", "ep2"}
+	backend := newFakeBackend(endpoints)
+	backend.blackhole("ep1", true)
+
+	b := NewBalancer(BalancerConfig{
+		Endpoints:     endpoints,
+		Probe:         backend.status,
+		ProbeInterval: 10 * time.Millisecond,
+		Metrics:       NewMetrics(),
+		Cluster:       "test",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go b.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		return !b.Status()["ep1"]
+	}, time.Second, 5*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		ep, err := b.Pick()
+		require.NoError(t, err)
+		require.Equal(t, "ep2", ep)
+	}
+
+	backend.blackhole("ep1", false)
+
+	require.Eventually(t, func() bool {
+		return b.Status()["ep1"]
+	}, time.Second, 5*time.Millisecond)
+}
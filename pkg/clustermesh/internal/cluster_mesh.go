@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/cilium/cilium/api/v1/models"
+	"github.com/cilium/cilium/pkg/clustermesh/types"
+	"github.com/cilium/cilium/pkg/hive"
+	"github.com/cilium/cilium/pkg/kvstore"
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+// Config holds the configuration common to the whole ClusterMesh engine.
+type Config struct {
+	// ClusterMeshConfig is the path to the directory containing one
+	// kvstore connectivity file per remote cluster, named after that
+	// cluster.
+	ClusterMeshConfig string
+}
+
+// StatusFunc returns the current status of a single remote cluster, for
+// aggregation into the overall ClusterMesh status.
+type StatusFunc func() *models.RemoteCluster
+
+// RemoteCluster is the interface implemented by a single remote cluster
+// connection, as constructed by a Configuration's NewRemoteCluster factory.
+type RemoteCluster interface {
+	// Run drives the connection to this remote cluster until ctx is
+	// cancelled or Stop is called.
+	Run(ctx context.Context)
+
+	// Stop tears down the connection to this remote cluster.
+	Stop()
+
+	// Ready reports whether this remote cluster has completed its initial
+	// sync and is actively connected.
+	Ready() bool
+}
+
+// Configuration configures a new ClusterMesh engine.
+type Configuration struct {
+	Config
+	types.ClusterIDName
+
+	// ClusterSizeDependantInterval scales an interval based on the size of
+	// the local cluster, used to derive the balancer's probe interval.
+	ClusterSizeDependantInterval kvstore.ClusterSizeDependantIntervalFunc
+
+	// NewRemoteCluster constructs a new RemoteCluster for the given name.
+	NewRemoteCluster func(name string, status StatusFunc) RemoteCluster
+
+	NodeName string
+	Metrics  Metrics
+}
+
+// ClusterMesh drives the discovery and lifecycle of every remote cluster
+// listed under Configuration.ClusterMeshConfig.
+type ClusterMesh struct {
+	conf Configuration
+
+	mutex          lock.RWMutex
+	remoteClusters map[string]RemoteCluster
+
+	cancel context.CancelFunc
+}
+
+// NewClusterMesh creates a new ClusterMesh engine. It must be registered
+// with a hive.Lifecycle (e.g. via lifecycle.Append(&cm)) to actually start
+// discovering and connecting to remote clusters.
+func NewClusterMesh(conf Configuration) ClusterMesh {
+	return ClusterMesh{
+		conf:           conf,
+		remoteClusters: make(map[string]RemoteCluster),
+	}
+}
+
+// Start implements hive.HookInterface. It discovers every remote cluster
+// listed under conf.ClusterMeshConfig and starts a RemoteCluster for each.
+func (cm *ClusterMesh) Start(hive.HookContext) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cm.cancel = cancel
+
+	entries, err := os.ReadDir(cm.conf.ClusterMeshConfig)
+	if err != nil {
+		// No configuration directory yet is not fatal: remotes may be
+		// added later via a config reload, which is out of scope here.
+		return nil
+	}
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := filepath.Base(entry.Name())
+		rc := cm.conf.NewRemoteCluster(name, cm.statusFuncFor(name))
+		cm.remoteClusters[name] = rc
+
+		go rc.Run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements hive.HookInterface.
+func (cm *ClusterMesh) Stop(hive.HookContext) error {
+	if cm.cancel != nil {
+		cm.cancel()
+	}
+
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	for _, rc := range cm.remoteClusters {
+		rc.Stop()
+	}
+
+	return nil
+}
+
+func (cm *ClusterMesh) statusFuncFor(name string) StatusFunc {
+	return func() *models.RemoteCluster {
+		cm.mutex.RLock()
+		rc, ok := cm.remoteClusters[name]
+		cm.mutex.RUnlock()
+
+		if !ok {
+			return nil
+		}
+
+		return &models.RemoteCluster{Name: name, Ready: rc.Ready()}
+	}
+}
+
+// ForEachRemoteCluster invokes fn for every known remote cluster, stopping
+// and returning the first error encountered.
+func (cm *ClusterMesh) ForEachRemoteCluster(fn func(RemoteCluster) error) error {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	for _, rc := range cm.remoteClusters {
+		if err := fn(rc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NumReadyClusters returns the number of remote clusters that have
+// completed their initial sync.
+func (cm *ClusterMesh) NumReadyClusters() int {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	n := 0
+	for _, rc := range cm.remoteClusters {
+		if rc.Ready() {
+			n++
+		}
+	}
+	return n
+}
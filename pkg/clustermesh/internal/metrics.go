@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package internal
+
+import (
+	"github.com/cilium/cilium/pkg/metrics/metric"
+)
+
+// Metrics holds the metrics exposed by this package.
+type Metrics struct {
+	// EndpointTransitions counts how many times a remote cluster endpoint
+	// transitioned between healthy and unhealthy, as tracked by Balancer.
+	EndpointTransitions metric.Vec[metric.Counter]
+}
+
+// NewMetrics returns a new Metrics instance backed by live metrics, for use
+// in production.
+func NewMetrics() Metrics {
+	return Metrics{
+		EndpointTransitions: metric.NewCounterVec(metric.CounterOpts{
+			Namespace: "cilium",
+			Subsystem: "clustermesh",
+			Name:      "remote_cluster_endpoint_transitions_total",
+			Help:      "Number of times a remote cluster kvstore endpoint transitioned between healthy and unhealthy",
+		}, []string{"cluster", "endpoint", "state"}),
+	}
+}
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package clustermesh
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cilium/cilium/api/v1/models"
+	"github.com/cilium/cilium/pkg/clustermesh/internal"
+	cmtypes "github.com/cilium/cilium/pkg/clustermesh/types"
+	"github.com/cilium/cilium/pkg/failpoint"
+	"github.com/cilium/cilium/pkg/ipcache"
+	"github.com/cilium/cilium/pkg/kvstore"
+	"github.com/cilium/cilium/pkg/kvstore/store"
+	"github.com/cilium/cilium/pkg/lock"
+	serviceStore "github.com/cilium/cilium/pkg/service/store"
+)
+
+// baseProbeInterval is the un-scaled interval at which the connection
+// balancer re-probes endpoints; it is passed through
+// ClusterSizeDependantInterval so that larger meshes probe less
+// aggressively.
+const baseProbeInterval = 5 * time.Second
+
+// remoteCluster represents a connection to a single remote cluster's
+// kvstore, with its node and service stores, and manages the health-aware
+// balancer used to pick which of that cluster's advertised endpoints to
+// connect through.
+type remoteCluster struct {
+	name   string
+	mesh   *ClusterMesh
+	status internal.StatusFunc
+	swg    *lock.StoppableWaitGroup
+
+	remoteNodes    *store.RestartableWatchStore
+	remoteServices *store.RestartableWatchStore
+	ipCacheWatcher *ipcache.IPIdentityWatcher
+
+	mutex    lock.RWMutex
+	config   *cmtypes.CiliumClusterConfig
+	balancer *internal.Balancer
+	ready    bool
+
+	cancel context.CancelFunc
+}
+
+// etcdConfig mirrors the on-disk layout of a single remote cluster's
+// kvstore connectivity file, as written under Config.ClusterMeshConfig.
+type etcdConfig struct {
+	Endpoints []string `yaml:"endpoints"`
+}
+
+// Run drives the connection to this remote cluster: it loads the
+// advertised endpoints, starts the health balancer over them, and then
+// repeatedly picks an endpoint, connects, and watches it until the stream
+// fails or ctx is cancelled, at which point the offending endpoint is
+// marked unhealthy and a new one is picked.
+func (rc *remoteCluster) Run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	rc.cancel = cancel
+
+	endpoints, err := rc.loadEndpoints()
+	if err != nil {
+		log.WithError(err).WithField("remoteCluster", rc.name).
+			Error("Failed to load remote cluster endpoints")
+		return
+	}
+
+	interval := baseProbeInterval
+	if rc.mesh.conf.ClusterSizeDependantInterval != nil {
+		interval = rc.mesh.conf.ClusterSizeDependantInterval(baseProbeInterval)
+	}
+
+	rc.mutex.Lock()
+	rc.balancer = internal.NewBalancer(internal.BalancerConfig{
+		Endpoints:     endpoints,
+		Probe:         rc.probeEndpoint,
+		ProbeInterval: interval,
+		Metrics:       rc.mesh.conf.InternalMetrics,
+		Cluster:       rc.name,
+	})
+	rc.mutex.Unlock()
+
+	go rc.balancer.Run(ctx)
+
+	for ctx.Err() == nil {
+		endpoint, err := rc.balancer.Pick()
+		if err != nil {
+			log.WithError(err).WithField("remoteCluster", rc.name).
+				Debug("No healthy remote cluster endpoint available, retrying")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+				continue
+			}
+		}
+
+		if err := rc.onUpdate(ctx, endpoint); err != nil {
+			log.WithError(err).WithFields(logrus.Fields{
+				"remoteCluster": rc.name,
+				"endpoint":      endpoint,
+			}).Warning("Remote cluster stream failed, marking endpoint unhealthy and re-dialing")
+			rc.balancer.MarkUnhealthy(endpoint)
+		}
+	}
+}
+
+// Stop tears down the connection to this remote cluster.
+func (rc *remoteCluster) Stop() {
+	if rc.cancel != nil {
+		rc.cancel()
+	}
+	rc.remoteNodes.Close()
+	rc.remoteServices.Close()
+	rc.ipCacheWatcher.Close()
+}
+
+// Ready implements internal.RemoteCluster.
+func (rc *remoteCluster) Ready() bool {
+	rc.mutex.RLock()
+	defer rc.mutex.RUnlock()
+	return rc.ready
+}
+
+// loadEndpoints parses this remote cluster's kvstore connectivity file.
+func (rc *remoteCluster) loadEndpoints() ([]string, error) {
+	path := filepath.Join(rc.mesh.conf.ClusterMeshConfig, rc.name)
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kvstore config for remote cluster %q: %w", rc.name, err)
+	}
+
+	var cfg etcdConfig
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse kvstore config for remote cluster %q: %w", rc.name, err)
+	}
+
+	if len(cfg.Endpoints) == 0 {
+		return nil, fmt.Errorf("remote cluster %q advertises no endpoints", rc.name)
+	}
+
+	return cfg.Endpoints, nil
+}
+
+// probeEndpoint is the Balancer's liveness probe for a single endpoint: a
+// short-lived etcd client issuing a single Status RPC.
+func (rc *remoteCluster) probeEndpoint(ctx context.Context, endpoint string) error {
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{endpoint},
+		DialTimeout: 2 * time.Second,
+		Context:     ctx,
+	})
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	_, err = cli.Status(ctx, endpoint)
+	return err
+}
+
+// onUpdate connects to the remote cluster through endpoint and blocks,
+// synchronizing the node and service stores, until the connection fails or
+// ctx is cancelled.
+func (rc *remoteCluster) onUpdate(ctx context.Context, endpoint string) error {
+	if err := failpoint.Inject(failpointDelayRemoteSync, func() error {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	backend, err := kvstore.NewClient(ctx, kvstore.EtcdBackendName, map[string]string{
+		kvstore.EtcdAddrOption: endpoint,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to remote cluster %q through %s: %w", rc.name, endpoint, err)
+	}
+	defer backend.Close()
+
+	rc.remoteNodes.Watch(ctx, backend)
+	rc.remoteServices.Watch(ctx, backend)
+
+	if err := rc.ipCacheWatcher.Watch(ctx, backend); err != nil {
+		return fmt.Errorf("ipcache watch on remote cluster %q failed: %w", rc.name, err)
+	}
+
+	rc.mutex.Lock()
+	rc.ready = true
+	rc.mutex.Unlock()
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Status returns the observed status of this remote cluster, including the
+// per-endpoint health tracked by its connection balancer.
+func (rc *remoteCluster) Status() *models.RemoteCluster {
+	rc.mutex.RLock()
+	balancer := rc.balancer
+	ready := rc.ready
+	rc.mutex.RUnlock()
+
+	status := &models.RemoteCluster{
+		Name:  rc.name,
+		Ready: ready,
+	}
+
+	if balancer != nil {
+		for endpoint, healthy := range balancer.Status() {
+			status.Endpoints = append(status.Endpoints, &models.RemoteClusterEndpointStatus{
+				Endpoint: endpoint,
+				Healthy:  healthy,
+			})
+		}
+	}
+
+	return status
+}
+
+// remoteServiceObserver forwards service updates discovered in a remote
+// cluster's kvstore into the local global service cache.
+type remoteServiceObserver struct {
+	remoteCluster *remoteCluster
+	swg           *lock.StoppableWaitGroup
+}
+
+func (r *remoteServiceObserver) OnUpdate(k store.Key) {
+	if err := failpoint.Inject(failpointDropIPCacheEvent, func() error {
+		return errDroppedByFailpoint
+	}); err != nil {
+		return
+	}
+
+	svc, ok := k.(*serviceStore.ClusterService)
+	if !ok {
+		return
+	}
+
+	r.remoteCluster.mesh.conf.ServiceMerger.MergeExternalServiceUpdate(svc, r.swg)
+}
+
+func (r *remoteServiceObserver) OnDelete(k store.Key) {
+	svc, ok := k.(*serviceStore.ClusterService)
+	if !ok {
+		return
+	}
+
+	r.remoteCluster.mesh.conf.ServiceMerger.MergeExternalServiceDelete(svc, r.swg)
+}
+
+// errDroppedByFailpoint is returned internally by the dropIPCacheEvent
+// failpoint to signal "discard this event" without implying a real error.
+var errDroppedByFailpoint = fmt.Errorf("event dropped by failpoint %s", failpointDropIPCacheEvent)
@@ -0,0 +1,80 @@
+//go:build failpoints
+
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package failpoint provides named fault injection points for use in tests,
+// following the pattern popularized by github.com/pingcap/failpoint. Unlike
+// that library, this package does not rewrite source at go generate time:
+// call sites simply guard an Inject() call behind this package's
+// `failpoints` build tag, so that a single build-tag switch compiles the
+// hooks in for test binaries and compiles them out (see
+// failpoint_disabled.go) for production ones.
+package failpoint
+
+import (
+	"fmt"
+
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+var (
+	mutex   lock.Mutex
+	enabled = make(map[string]struct{})
+)
+
+// Enable activates the named injection point. Subsequent calls to Inject
+// with the same name will run their callback until Disable is called.
+func Enable(name string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	enabled[name] = struct{}{}
+	return nil
+}
+
+// Disable deactivates the named injection point.
+func Disable(name string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, ok := enabled[name]; !ok {
+		return fmt.Errorf("failpoint %q is not enabled", name)
+	}
+
+	delete(enabled, name)
+	return nil
+}
+
+// Inject runs fn and returns its result if the named injection point has
+// been enabled via Enable; it is a no-op returning nil otherwise. Because
+// fn returns an error, a call site can use its result to force a specific
+// error return or an early return from the caller, in addition to sleeps
+// and other side effects, e.g.:
+//
+//	if err := failpoint.Inject("clustermesh/delayRemoteSync", func() error {
+//		time.Sleep(5 * time.Second)
+//		return nil
+//	}); err != nil {
+//		return err
+//	}
+func Inject(name string, fn func() error) error {
+	mutex.Lock()
+	_, ok := enabled[name]
+	mutex.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	return fn()
+}
+
+// Enabled reports whether the named injection point is currently active.
+func Enabled(name string) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	_, ok := enabled[name]
+	return ok
+}
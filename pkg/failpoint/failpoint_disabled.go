@@ -0,0 +1,32 @@
+//go:build !failpoints
+
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package failpoint
+
+import "fmt"
+
+// Enable always fails outside of a `failpoints`-tagged test build, so that
+// a test which forgets to add the build tag fails loudly instead of
+// silently skipping its injected fault.
+func Enable(name string) error {
+	return fmt.Errorf("failpoints are disabled in this build (missing -tags failpoints)")
+}
+
+// Disable is a no-op outside of a `failpoints`-tagged build.
+func Disable(name string) error {
+	return nil
+}
+
+// Inject is a no-op outside of a `failpoints`-tagged build, and compiles
+// down to nothing so that production binaries pay no cost for call sites
+// that guard behind it.
+func Inject(name string, fn func() error) error {
+	return nil
+}
+
+// Enabled always reports false outside of a `failpoints`-tagged build.
+func Enabled(name string) bool {
+	return false
+}
@@ -0,0 +1,45 @@
+//go:build failpoints
+
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package failpoint
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectRunsOnlyWhenEnabled(t *testing.T) {
+	const name = "failpoint/testInjectRunsOnlyWhenEnabled"
+
+	var ran bool
+	require.NoError(t, Inject(name, func() error { ran = true; return nil }))
+	require.False(t, ran)
+
+	require.NoError(t, Enable(name))
+	require.True(t, Enabled(name))
+
+	require.NoError(t, Inject(name, func() error { ran = true; return nil }))
+	require.True(t, ran)
+
+	require.NoError(t, Disable(name))
+	require.False(t, Enabled(name))
+}
+
+func TestInjectPropagatesForcedError(t *testing.T) {
+	const name = "failpoint/testInjectPropagatesForcedError"
+	forced := errors.New("forced failure")
+
+	require.NoError(t, Enable(name))
+	t.Cleanup(func() { Disable(name) })
+
+	err := Inject(name, func() error { return forced })
+	require.ErrorIs(t, err, forced)
+}
+
+func TestDisableUnknownFailpoint(t *testing.T) {
+	require.Error(t, Disable("failpoint/neverEnabled"))
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package v2alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CiliumBGPNeighbor is a neighboring peer to be used by the BGP Control
+// Plane.
+type CiliumBGPNeighbor struct {
+	// PeerAddress is the IP address of the peer, reachable on the network.
+	PeerAddress string `json:"peerAddress"`
+
+	// PeerASN is the ASN of the peer BGP router.
+	PeerASN uint32 `json:"peerASN"`
+
+	// PeerPort is the TCP port of the peer. 179 is used if unset.
+	//
+	// +optional
+	PeerPort *int `json:"peerPort,omitempty"`
+
+	// AuthPassword, if set, configures the TCP MD5 signature (RFC 2385)
+	// shared secret used to authenticate the session with this peer
+	// in-line. AuthSecretRef should be preferred over this field, since a
+	// literal password ends up in the CiliumBGPNeighbor object itself.
+	//
+	// +optional
+	AuthPassword string `json:"authPassword,omitempty"`
+
+	// AuthSecretRef references a Secret containing the shared secret used
+	// to authenticate the session with this peer, under the "password"
+	// key. When both AuthPassword and AuthSecretRef are set, AuthSecretRef
+	// takes precedence.
+	//
+	// +optional
+	AuthSecretRef *corev1.SecretReference `json:"authSecretRef,omitempty"`
+
+	// TCPAO holds RFC 5925 TCP Authentication Option parameters for this
+	// peer. The gobgp backend does not read this field: it authenticates
+	// sessions with TCP MD5 signatures only.
+	//
+	// +optional
+	TCPAO *CiliumBGPNeighborTCPAO `json:"tcpAO,omitempty"`
+
+	// ConnectRetryTime defines how long the BGP control plane waits
+	// between connection attempts to this peer.
+	ConnectRetryTime metav1.Duration `json:"connectRetryTime"`
+
+	// HoldTime defines the initial value for the BGP hold timer, as
+	// negotiated with this peer.
+	HoldTime metav1.Duration `json:"holdTime"`
+
+	// KeepAliveTime defines the initial interval between keep-alive
+	// messages sent to this peer.
+	KeepAliveTime metav1.Duration `json:"keepAliveTime"`
+
+	// GracefulRestart configures graceful restart for this peer.
+	//
+	// +optional
+	GracefulRestart CiliumBGPNeighborGracefulRestart `json:"gracefulRestart,omitempty"`
+
+	// EBGPMultihopTTL controls the TTL used on outgoing packets for eBGP
+	// peers that are not directly connected. Ignored for iBGP peers.
+	//
+	// +optional
+	EBGPMultihopTTL int32 `json:"eBGPMultihopTTL,omitempty"`
+}
+
+// CiliumBGPNeighborGracefulRestart defines the graceful restart options for
+// a CiliumBGPNeighbor.
+type CiliumBGPNeighborGracefulRestart struct {
+	// Enabled flags this neighbor for graceful restart.
+	//
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// RestartTime defines the duration this peer should be given to
+	// restart before being considered down.
+	//
+	// +optional
+	RestartTime metav1.Duration `json:"restartTime,omitempty"`
+}
+
+// CiliumBGPNeighborTCPAO configures RFC 5925 TCP Authentication Option
+// parameters for a CiliumBGPNeighbor.
+type CiliumBGPNeighborTCPAO struct {
+	// KeyID identifies this key among the set of keys configured for the
+	// peer, allowing key rotation without dropping the session.
+	KeyID uint8 `json:"keyID"`
+
+	// Algorithm names the MAC algorithm used to authenticate segments,
+	// e.g. "hmac-sha-1-96" or "aes-128-cmac-96".
+	Algorithm string `json:"algorithm"`
+}